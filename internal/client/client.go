@@ -0,0 +1,224 @@
+// Package client implements a minimal, retrying HTTP client for the Regery
+// DNS API, shared by the libdns provider methods.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL is the Regery API endpoint used when a Provider does not
+// override it.
+const DefaultBaseURL = "https://api.regery.com/v1/domains"
+
+const userAgent = "libdns-regery"
+
+const (
+	maxAttempts  = 5
+	initialDelay = 500 * time.Millisecond
+	maxDelay     = 30 * time.Second
+)
+
+// Client is a low-level Regery API client. It owns a single *http.Client and
+// is safe for concurrent use.
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	APIToken   string
+	Secret     string
+}
+
+// New returns a Client configured with the given credentials. httpClient may
+// be nil, in which case http.DefaultClient is used.
+func New(apiToken, secret, baseURL string, httpClient *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		HTTPClient: httpClient,
+		BaseURL:    baseURL,
+		APIToken:   apiToken,
+		Secret:     secret,
+	}
+}
+
+// APIError is returned when Regery responds with a non-2xx status code that
+// isn't resolved by retrying.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("regery: unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Record mirrors the JSON representation of a DNS record in the Regery API.
+// Priority, Weight, Port, Flags and Tag are only populated for record types
+// that use them (MX/SRV, SRV, CAA respectively).
+type Record struct {
+	Address  string `json:"address"`
+	Value    string `json:"value"`
+	Type     string `json:"type"`
+	TTL      int    `json:"ttl,omitempty"`
+	Name     string `json:"name"`
+	Priority int    `json:"priority,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Flags    int    `json:"flags,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+// Records wraps the "records" envelope Regery expects on requests and
+// returns on responses.
+type Records struct {
+	Records []Record `json:"records"`
+}
+
+// GetRecords fetches all records for the given zone.
+func (c *Client) GetRecords(ctx context.Context, zone string) (Records, error) {
+	var out Records
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/%s/records", zone), nil, &out)
+	return out, err
+}
+
+// AppendRecords creates the given records in the zone.
+func (c *Client) AppendRecords(ctx context.Context, zone string, records Records) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/%s/records", zone), records, nil)
+}
+
+// DeleteRecords removes the given records from the zone.
+func (c *Client) DeleteRecords(ctx context.Context, zone string, records Records) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/%s/records", zone), records, nil)
+}
+
+// Domain is an account-level domain returned by the domains listing
+// endpoint.
+type Domain struct {
+	Name string `json:"name"`
+}
+
+// DomainsPage is one page of the account's domains listing.
+type DomainsPage struct {
+	Domains    []Domain `json:"domains"`
+	Page       int      `json:"page"`
+	TotalPages int      `json:"total_pages"`
+}
+
+// ListDomains fetches one page (1-indexed) of the account's domains.
+func (c *Client) ListDomains(ctx context.Context, page int) (DomainsPage, error) {
+	var out DomainsPage
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("?page=%d", page), nil, &out)
+	return out, err
+}
+
+// do issues a single Regery API call, retrying on 429 and 5xx responses with
+// exponential backoff (honoring Retry-After when present), and decodes the
+// response body into out when it is non-nil.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("regery: encoding request: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var bodyReader io.Reader
+		if payload != nil {
+			bodyReader = bytes.NewReader(payload)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
+		if err != nil {
+			return fmt.Errorf("regery: building request: %w", err)
+		}
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Authorization", fmt.Sprintf("%s:%s", c.APIToken, c.Secret))
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("regery: making request: %w", err)
+		}
+
+		delay, apiErr, err := c.handleResponse(resp, out, attempt)
+		if err == nil {
+			return nil
+		}
+		if apiErr == nil {
+			return err
+		}
+		lastErr = apiErr
+
+		if attempt == maxAttempts-1 {
+			break
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("regery: giving up after %d attempts: %w", maxAttempts, lastErr)
+}
+
+// handleResponse closes resp.Body, decodes it into out on success, and
+// returns the retry delay alongside the error when the status code (429 or
+// 5xx) warrants a retry.
+func (c *Client) handleResponse(resp *http.Response, out any, attempt int) (time.Duration, *APIError, error) {
+	defer resp.Body.Close()
+
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("regery: reading response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(contents)}
+		return retryDelay(resp, attempt), apiErr, apiErr
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, nil, &APIError{StatusCode: resp.StatusCode, Body: string(contents)}
+	}
+
+	if out == nil || len(contents) == 0 {
+		return 0, nil, nil
+	}
+	if err := json.Unmarshal(contents, out); err != nil {
+		return 0, nil, fmt.Errorf("regery: decoding response: %w", err)
+	}
+	return 0, nil, nil
+}
+
+// retryDelay honors a Retry-After header (seconds) when present, falling
+// back to exponential backoff based on the attempt number.
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if ra := resp.Header.Get("Retry-After"); ra != "" {
+		if seconds, err := strconv.Atoi(ra); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	delay := time.Duration(float64(initialDelay) * math.Pow(2, float64(attempt)))
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientRetriesOn5xxThenSucceeds(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"records":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New("token", "secret", srv.URL, srv.Client())
+
+	if _, err := c.GetRecords(context.Background(), "example.com"); err != nil {
+		t.Fatalf("GetRecords() error = %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestClientHonorsRetryAfter(t *testing.T) {
+	var attempts int
+	var firstAttempt, secondAttempt time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			firstAttempt = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		secondAttempt = time.Now()
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"records":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New("token", "secret", srv.URL, srv.Client())
+
+	if _, err := c.GetRecords(context.Background(), "example.com"); err != nil {
+		t.Fatalf("GetRecords() error = %v, want nil", err)
+	}
+	if gap := secondAttempt.Sub(firstAttempt); gap < time.Second {
+		t.Fatalf("retry happened after %v, want at least 1s (Retry-After)", gap)
+	}
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New("token", "secret", srv.URL, srv.Client())
+
+	if _, err := c.GetRecords(context.Background(), "example.com"); err == nil {
+		t.Fatal("GetRecords() error = nil, want error after exhausting retries")
+	}
+	if attempts != maxAttempts {
+		t.Fatalf("attempts = %d, want %d", attempts, maxAttempts)
+	}
+}
+
+func TestClientNonRetryableErrorReturnsImmediately(t *testing.T) {
+	var attempts int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	c := New("token", "secret", srv.URL, srv.Client())
+
+	_, err := c.GetRecords(context.Background(), "example.com")
+	if err == nil {
+		t.Fatal("GetRecords() error = nil, want error")
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (non-retryable status shouldn't retry)", attempts)
+	}
+}
@@ -3,192 +3,213 @@
 package libdnsregery
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/libdns/libdns"
+	"github.com/libdns/regery/internal/client"
 )
 
-type RegeryDNSRecord struct {
-	Address string `json:"address"`
-	Value   string `json:"value"`
-	Type    string `json:"type"`
-	TTL     int    `json:"ttl,omitempty"`
-	Name    string `json:"name"`
-}
-
-type RegeryDNSRecords struct {
-	Records []RegeryDNSRecord `json:"records"`
-}
-
 // Provider facilitates DNS record manipulation with Regery.
 type Provider struct {
 	APIToken string `json:"api_token,omitempty"`
 	Secret   string `json:"secret"`
-}
 
-const baseUrl = "https://api.regery.com/v1/domains"
+	// HTTPClient is used for all API calls. If nil, a client with a sane
+	// default timeout is used. Exposed mainly so tests can inject a client
+	// pointed at an httptest.Server.
+	HTTPClient *http.Client `json:"-"`
 
-// GetRecords lists all the records in the zone.
-func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
-	url := fmt.Sprintf("%s/%s/records", baseUrl, zone)
+	// BaseURL overrides the Regery API endpoint. If empty, client.DefaultBaseURL
+	// is used. Exposed mainly so tests can point it at an httptest.Server.
+	BaseURL string `json:"-"`
 
-	req, err := http.NewRequest("GET", url, nil)
-	req.Header.Add("Authorization", fmt.Sprintf("%s:%s", p.APIToken, p.Secret))
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatalf("Failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
+	client     *client.Client
+	clientOnce sync.Once
+	zoneLock   zoneLock
+}
 
-	if resp.StatusCode != http.StatusOK {
-		contents, _ := io.ReadAll(resp.Body)
-		log.Fatalf("Received non-200 response: %d %s", resp.StatusCode, contents)
-	}
+func (p *Provider) getClient() *client.Client {
+	p.clientOnce.Do(func() {
+		httpClient := p.HTTPClient
+		if httpClient == nil {
+			httpClient = &http.Client{Timeout: 30 * time.Second}
+		}
+		p.client = client.New(p.APIToken, p.Secret, p.BaseURL, httpClient)
+	})
+	return p.client
+}
 
-	body, err := io.ReadAll(resp.Body)
+// GetRecords lists all the records in the zone.
+func (p *Provider) GetRecords(ctx context.Context, zone string) ([]libdns.Record, error) {
+	result, err := p.getClient().GetRecords(ctx, zone)
 	if err != nil {
-		log.Fatalf("Failed to read response body: %v", err)
-		return nil, err
-	}
-
-	var result RegeryDNSRecords
-	if err := json.Unmarshal(body, &result); err != nil {
-		log.Fatalf("Failed to parse JSON: %v", err)
 		return nil, err
 	}
 
 	var records []libdns.Record
 	for _, record := range result.Records {
-		var value string
-		if record.Value == "" {
-			value = record.Address
-		} else {
-			value = record.Value
-		}
-		records = append(records, libdns.Record{
-			ID:    record.Name,
-			TTL:   time.Duration(record.TTL) * time.Second,
-			Type:  record.Type,
-			Name:  record.Name,
-			Value: value,
-		})
+		records = append(records, fromRegeryRecord(record))
 	}
 	return records, nil
 }
 
-func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	url := fmt.Sprintf("%s/%s/records", baseUrl, zone)
+// ListZones returns the domains manageable by the account, paginating
+// through Regery's account-level domains listing.
+func (p *Provider) ListZones(ctx context.Context) ([]libdns.Zone, error) {
+	var zones []libdns.Zone
 
-	var regeryRecords []RegeryDNSRecord
-	for _, r := range records {
-		regeryRecord := toRegeryDNSRecord(r)
-		regeryRecords = append(regeryRecords, regeryRecord)
-	}
+	for page := 1; ; page++ {
+		result, err := p.getClient().ListDomains(ctx, page)
+		if err != nil {
+			return nil, err
+		}
 
-	request, err := json.Marshal(RegeryDNSRecords{regeryRecords})
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(request))
-	req.Header.Add("Authorization", fmt.Sprintf("%s:%s", p.APIToken, p.Secret))
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatalf("Failed to make request: %v", err)
-	}
-	defer resp.Body.Close()
+		for _, d := range result.Domains {
+			zones = append(zones, libdns.Zone{Name: d.Name})
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		contents, _ := io.ReadAll(resp.Body)
-		log.Fatalf("Received non-200 response: %d\n%s\n%s\n%+v", resp.StatusCode, contents, request, records)
+		if result.TotalPages == 0 || page >= result.TotalPages {
+			break
+		}
 	}
 
-	return records, nil
+	return zones, nil
+}
+
+// AppendRecords adds the given records to the zone. It returns the records
+// that were added.
+func (p *Provider) AppendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	return p.appendRecords(ctx, zone, records)
 }
 
 // SetRecords sets the records in the zone, either by updating existing records or creating new ones.
 // It returns the updated records.
+//
+// The read-modify-write cycle is serialized per zone so that concurrent
+// SetRecords/DeleteRecords calls against the same zone (e.g. two DNS-01
+// challenges for different names under the same zone) can't race on a stale
+// "existing records" snapshot.
 func (p *Provider) SetRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	var err error
+	if err := p.zoneLock.lock(ctx, zone); err != nil {
+		return nil, err
+	}
+	defer p.zoneLock.unlock(zone)
 
 	existingRecords, err := p.GetRecords(ctx, zone)
 	if err != nil {
 		return nil, err
 	}
 
-	var toDelete []libdns.Record
-	for _, r := range existingRecords {
-		for _, newRecord := range records {
-			if newRecord.Name == r.Name {
-				toDelete = append(toDelete, r)
-			}
-		}
-	}
+	toDelete := recordsToReplace(existingRecords, records)
 
-	appendedRecords, err := p.AppendRecords(ctx, zone, records)
+	appendedRecords, err := p.appendRecords(ctx, zone, records)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = p.DeleteRecords(ctx, zone, toDelete)
-	if err != nil {
-		log.Printf("Failed to delete records that were overwritten, %s", err)
+	if _, err := p.deleteRecords(ctx, zone, toDelete); err != nil {
+		// appendedRecords already exist server-side at this point, so they
+		// must still be returned: a caller that retries SetRecords on a nil
+		// result would re-append them and create duplicates.
+		return appendedRecords, fmt.Errorf("regery: appended records but failed to delete superseded records: %w", err)
 	}
 
 	return appendedRecords, nil
 }
 
-// DeleteRecords deletes the records from the zone. It returns the records that were deleted.
+// DeleteRecords deletes the records from the zone. It returns the records
+// that were deleted.
 func (p *Provider) DeleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
-	url := fmt.Sprintf("%s/%s/records", baseUrl, zone)
+	if err := p.zoneLock.lock(ctx, zone); err != nil {
+		return nil, err
+	}
+	defer p.zoneLock.unlock(zone)
 
-	var regeryRecords []RegeryDNSRecord
+	return p.deleteRecords(ctx, zone, records)
+}
+
+func (p *Provider) appendRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	regeryRecords := make([]client.Record, 0, len(records))
 	for _, r := range records {
-		regeryRecord := toRegeryDNSRecord(r)
+		regeryRecord, err := toRegeryRecord(r)
+		if err != nil {
+			return nil, err
+		}
 		regeryRecords = append(regeryRecords, regeryRecord)
 	}
 
-	request, err := json.Marshal(RegeryDNSRecords{regeryRecords})
-	req, err := http.NewRequest("DELETE", url, bytes.NewBuffer(request))
-	req.Header.Add("Authorization", fmt.Sprintf("%s:%s", p.APIToken, p.Secret))
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Fatalf("Failed to make request: %v", err)
+	if err := p.getClient().AppendRecords(ctx, zone, client.Records{Records: regeryRecords}); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return records, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		contents, _ := io.ReadAll(resp.Body)
-		log.Fatalf("Received non-200 response: %d\n%s", resp.StatusCode, contents)
+func (p *Provider) deleteRecords(ctx context.Context, zone string, records []libdns.Record) ([]libdns.Record, error) {
+	regeryRecords := make([]client.Record, 0, len(records))
+	for _, r := range records {
+		regeryRecord, err := toRegeryRecord(r)
+		if err != nil {
+			return nil, err
+		}
+		regeryRecords = append(regeryRecords, regeryRecord)
 	}
 
+	if err := p.getClient().DeleteRecords(ctx, zone, client.Records{Records: regeryRecords}); err != nil {
+		return nil, err
+	}
 	return records, nil
 }
 
+// recordsToReplace returns the subset of existingRecords that should be
+// deleted when setting wantRecords. Most record types are matched on the
+// (Name, Type) tuple, replacing the whole RRset. Types where multiple
+// values legitimately coexist at the same name (TXT, MX) are instead
+// matched on the exact (Name, Type, Value) triple, so unrelated values at
+// the same name+type are left alone.
+func recordsToReplace(existingRecords, wantRecords []libdns.Record) []libdns.Record {
+	wantKeys := make(map[string]bool, len(wantRecords))
+	wantValueKeys := make(map[string]bool, len(wantRecords))
+	for _, r := range wantRecords {
+		wantKeys[recordKey(r.Name, r.Type)] = true
+		wantValueKeys[recordValueKey(r.Name, r.Type, r.Value)] = true
+	}
+
+	var toDelete []libdns.Record
+	for _, existing := range existingRecords {
+		if isMultiValueType(existing.Type) {
+			if wantValueKeys[recordValueKey(existing.Name, existing.Type, existing.Value)] {
+				toDelete = append(toDelete, existing)
+			}
+			continue
+		}
+		if wantKeys[recordKey(existing.Name, existing.Type)] {
+			toDelete = append(toDelete, existing)
+		}
+	}
+	return toDelete
+}
+
+func isMultiValueType(recordType string) bool {
+	return recordType == "TXT" || recordType == "MX"
+}
+
+func recordKey(name, recordType string) string {
+	return name + "\x00" + recordType
+}
+
+func recordValueKey(name, recordType, value string) string {
+	return recordKey(name, recordType) + "\x00" + value
+}
+
 // Interface guards
 var (
 	_ libdns.RecordGetter   = (*Provider)(nil)
 	_ libdns.RecordAppender = (*Provider)(nil)
 	_ libdns.RecordSetter   = (*Provider)(nil)
 	_ libdns.RecordDeleter  = (*Provider)(nil)
+	_ libdns.ZoneLister     = (*Provider)(nil)
 )
-
-func toRegeryDNSRecord(r libdns.Record) RegeryDNSRecord {
-	var ttlSeconds int
-	ttlSeconds = int(r.TTL.Seconds())
-	if ttlSeconds == 0 {
-		ttlSeconds = 3600
-	}
-	return RegeryDNSRecord{
-		Address: r.Value,
-		Value:   r.Value,
-		Type:    r.Type,
-		TTL:     ttlSeconds,
-		Name:    r.Name,
-	}
-}
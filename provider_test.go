@@ -0,0 +1,73 @@
+package libdnsregery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/libdns/regery/internal/client"
+)
+
+func TestListZonesPaginates(t *testing.T) {
+	pages := []client.DomainsPage{
+		{Domains: []client.Domain{{Name: "a.com"}, {Name: "b.com"}}, Page: 1, TotalPages: 2},
+		{Domains: []client.Domain{{Name: "c.com"}}, Page: 2, TotalPages: 2},
+	}
+
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		page := r.URL.Query().Get("page")
+		for _, p := range pages {
+			if page == "1" && p.Page == 1 || page == "2" && p.Page == 2 {
+				json.NewEncoder(w).Encode(p)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	p := &Provider{HTTPClient: srv.Client(), BaseURL: srv.URL}
+
+	zones, err := p.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones() error = %v", err)
+	}
+
+	want := []string{"a.com", "b.com", "c.com"}
+	if len(zones) != len(want) {
+		t.Fatalf("got %d zones, want %d", len(zones), len(want))
+	}
+	for i, z := range zones {
+		if z.Name != want[i] {
+			t.Errorf("zones[%d].Name = %q, want %q", i, z.Name, want[i])
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("made %d requests, want 2 (one per page)", len(requests))
+	}
+}
+
+func TestListZonesSinglePage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(client.DomainsPage{
+			Domains:    []client.Domain{{Name: "only.com"}},
+			Page:       1,
+			TotalPages: 1,
+		})
+	}))
+	defer srv.Close()
+
+	p := &Provider{HTTPClient: srv.Client(), BaseURL: srv.URL}
+
+	zones, err := p.ListZones(context.Background())
+	if err != nil {
+		t.Fatalf("ListZones() error = %v", err)
+	}
+	if len(zones) != 1 || zones[0].Name != "only.com" {
+		t.Fatalf("got %+v, want a single zone named only.com", zones)
+	}
+}
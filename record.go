@@ -0,0 +1,170 @@
+package libdnsregery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libdns/libdns"
+	"github.com/libdns/regery/internal/client"
+)
+
+const defaultTTLSeconds = 3600
+
+// txtChunkSize is the maximum length of a single <character-string> within a
+// TXT record's value, per RFC 1035.
+const txtChunkSize = 255
+
+// toRegeryRecord converts a libdns.Record into its Regery wire
+// representation, pulling MX/SRV priority and weight from the struct fields
+// libdns.Record already provides for them, and rendering the remaining
+// type-specific fields (SRV port, CAA flags/tag, chunked TXT).
+func toRegeryRecord(r libdns.Record) (client.Record, error) {
+	ttlSeconds := int(r.TTL.Seconds())
+	if ttlSeconds == 0 {
+		ttlSeconds = defaultTTLSeconds
+	}
+
+	rec := client.Record{
+		Type: r.Type,
+		TTL:  ttlSeconds,
+		Name: r.Name,
+	}
+
+	switch r.Type {
+	case "MX":
+		rec.Priority = int(r.Priority)
+		rec.Address, rec.Value = r.Value, r.Value
+
+	case "SRV":
+		port, target, err := parseSRVValue(r.Value)
+		if err != nil {
+			return client.Record{}, err
+		}
+		rec.Priority, rec.Weight, rec.Port = int(r.Priority), int(r.Weight), port
+		rec.Address, rec.Value = target, target
+
+	case "CAA":
+		flags, tag, value, err := parseCAAValue(r.Value)
+		if err != nil {
+			return client.Record{}, err
+		}
+		rec.Flags, rec.Tag = flags, tag
+		rec.Address, rec.Value = value, value
+
+	case "TXT":
+		chunked := chunkTXTValue(r.Value)
+		rec.Address, rec.Value = chunked, chunked
+
+	default:
+		rec.Address, rec.Value = r.Value, r.Value
+	}
+
+	return rec, nil
+}
+
+// fromRegeryRecord converts a Regery wire record back into a libdns.Record,
+// populating Priority/Weight from the dedicated struct fields and
+// reassembling the remaining type-specific fields into Value.
+func fromRegeryRecord(r client.Record) libdns.Record {
+	value := r.Value
+	if value == "" {
+		value = r.Address
+	}
+
+	rec := libdns.Record{
+		ID:   r.Name,
+		TTL:  time.Duration(r.TTL) * time.Second,
+		Type: r.Type,
+		Name: r.Name,
+	}
+
+	switch r.Type {
+	case "MX":
+		rec.Priority = uint(r.Priority)
+		rec.Value = value
+	case "SRV":
+		rec.Priority = uint(r.Priority)
+		rec.Weight = uint(r.Weight)
+		rec.Value = fmt.Sprintf("%d %s", r.Port, value)
+	case "CAA":
+		rec.Value = fmt.Sprintf("%d %s %q", r.Flags, r.Tag, value)
+	case "TXT":
+		rec.Value = unchunkTXTValue(value)
+	default:
+		rec.Value = value
+	}
+
+	return rec
+}
+
+func parseSRVValue(value string) (port int, target string, err error) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return 0, "", fmt.Errorf("regery: invalid SRV value %q, want \"port target\"", value)
+	}
+	port, err = strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("regery: invalid SRV port in %q: %w", value, err)
+	}
+	return port, fields[1], nil
+}
+
+func parseCAAValue(value string) (flags int, tag, caaValue string, err error) {
+	fields := strings.SplitN(value, " ", 3)
+	if len(fields) != 3 {
+		return 0, "", "", fmt.Errorf("regery: invalid CAA value %q, want \"flags tag \\\"value\\\"\"", value)
+	}
+	flags, err = strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, "", "", fmt.Errorf("regery: invalid CAA flags in %q: %w", value, err)
+	}
+	unquoted, unquoteErr := strconv.Unquote(fields[2])
+	if unquoteErr != nil {
+		unquoted = strings.Trim(fields[2], `"`)
+	}
+	return flags, fields[1], unquoted, nil
+}
+
+// chunkTXTValue splits value into 255-byte <character-string> chunks, each
+// rendered as a quoted segment, when it exceeds a single chunk. Short values
+// are left as-is so simple TXT records round-trip unchanged.
+func chunkTXTValue(value string) string {
+	if len(value) <= txtChunkSize {
+		return value
+	}
+
+	var chunks []string
+	for i := 0; i < len(value); i += txtChunkSize {
+		end := i + txtChunkSize
+		if end > len(value) {
+			end = len(value)
+		}
+		chunks = append(chunks, strconv.Quote(value[i:end]))
+	}
+	return strings.Join(chunks, " ")
+}
+
+var quotedChunkPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// unchunkTXTValue reverses chunkTXTValue, joining quoted chunks back into a
+// single string. Values that do not begin with a double quote are returned
+// unchanged; a value that does is treated as quoted chunks even if it was
+// never chunked.
+func unchunkTXTValue(value string) string {
+	if !strings.HasPrefix(strings.TrimSpace(value), `"`) {
+		return value
+	}
+
+	var b strings.Builder
+	for _, chunk := range quotedChunkPattern.FindAllString(value, -1) {
+		unquoted, err := strconv.Unquote(chunk)
+		if err != nil {
+			unquoted = strings.Trim(chunk, `"`)
+		}
+		b.WriteString(unquoted)
+	}
+	return b.String()
+}
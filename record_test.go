@@ -0,0 +1,105 @@
+package libdnsregery
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libdns/libdns"
+)
+
+func TestToRegeryRecordMX(t *testing.T) {
+	r := libdns.Record{
+		Type:     "MX",
+		Name:     "@",
+		Value:    "mail.example.com.",
+		Priority: 10,
+		TTL:      time.Hour,
+	}
+
+	rec, err := toRegeryRecord(r)
+	if err != nil {
+		t.Fatalf("toRegeryRecord() error = %v", err)
+	}
+	if rec.Priority != 10 {
+		t.Errorf("Priority = %d, want 10", rec.Priority)
+	}
+	if rec.Value != "mail.example.com." {
+		t.Errorf("Value = %q, want %q", rec.Value, "mail.example.com.")
+	}
+}
+
+func TestToRegeryRecordSRV(t *testing.T) {
+	r := libdns.Record{
+		Type:     "SRV",
+		Name:     "_sip._tcp",
+		Value:    "5060 sip.example.com.",
+		Priority: 10,
+		Weight:   5,
+	}
+
+	rec, err := toRegeryRecord(r)
+	if err != nil {
+		t.Fatalf("toRegeryRecord() error = %v", err)
+	}
+	if rec.Priority != 10 || rec.Weight != 5 || rec.Port != 5060 {
+		t.Errorf("got priority=%d weight=%d port=%d, want 10/5/5060", rec.Priority, rec.Weight, rec.Port)
+	}
+	if rec.Value != "sip.example.com." {
+		t.Errorf("Value = %q, want %q", rec.Value, "sip.example.com.")
+	}
+}
+
+func TestRegeryRecordRoundTripMXAndSRV(t *testing.T) {
+	cases := []libdns.Record{
+		{Type: "MX", Name: "@", Value: "mail.example.com.", Priority: 10},
+		{Type: "SRV", Name: "_sip._tcp", Value: "5060 sip.example.com.", Priority: 10, Weight: 5},
+	}
+
+	for _, want := range cases {
+		rec, err := toRegeryRecord(want)
+		if err != nil {
+			t.Fatalf("toRegeryRecord(%+v) error = %v", want, err)
+		}
+		got := fromRegeryRecord(rec)
+		if got.Priority != want.Priority || got.Weight != want.Weight || got.Value != want.Value {
+			t.Errorf("round-trip of %+v = %+v", want, got)
+		}
+	}
+}
+
+func TestTXTValueChunking(t *testing.T) {
+	short := "hello world"
+	if got := chunkTXTValue(short); got != short {
+		t.Errorf("chunkTXTValue(short) = %q, want unchanged %q", got, short)
+	}
+	if got := unchunkTXTValue(short); got != short {
+		t.Errorf("unchunkTXTValue(short) = %q, want unchanged %q", got, short)
+	}
+
+	long := strings.Repeat("a", 300)
+	chunked := chunkTXTValue(long)
+	if chunked == long {
+		t.Fatalf("chunkTXTValue(long) did not chunk a 300-byte value")
+	}
+	if got := unchunkTXTValue(chunked); got != long {
+		t.Errorf("unchunkTXTValue(chunkTXTValue(long)) = %q, want original value back", got)
+	}
+}
+
+func TestCAAValueConversion(t *testing.T) {
+	r := libdns.Record{Type: "CAA", Name: "@", Value: `0 issue "letsencrypt.org"`}
+
+	rec, err := toRegeryRecord(r)
+	if err != nil {
+		t.Fatalf("toRegeryRecord() error = %v", err)
+	}
+	if rec.Flags != 0 || rec.Tag != "issue" || rec.Value != "letsencrypt.org" {
+		t.Errorf("got flags=%d tag=%q value=%q, want 0/issue/letsencrypt.org", rec.Flags, rec.Tag, rec.Value)
+	}
+
+	got := fromRegeryRecord(rec)
+	if got.Value != r.Value {
+		t.Errorf("fromRegeryRecord(toRegeryRecord(r)).Value = %q, want %q", got.Value, r.Value)
+	}
+}
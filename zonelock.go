@@ -0,0 +1,49 @@
+package libdnsregery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// zoneLock serializes read-modify-write operations against a single zone so
+// that, e.g., two goroutines solving DNS-01 challenges for different names
+// under the same zone don't both read the same "existing records" snapshot
+// and clobber each other's writes. A mutex guards a reference count per
+// zone, and callers poll until the zone is uncontended.
+type zoneLock struct {
+	mu     sync.Mutex
+	active map[string]int
+}
+
+const zoneLockPollInterval = 100 * time.Millisecond
+
+// lock blocks until the zone has no other active holder, then claims it. It
+// returns ctx.Err() without claiming the zone if ctx is done first.
+func (z *zoneLock) lock(ctx context.Context, zone string) error {
+	for {
+		z.mu.Lock()
+		if z.active == nil {
+			z.active = make(map[string]int)
+		}
+		if z.active[zone] == 0 {
+			z.active[zone]++
+			z.mu.Unlock()
+			return nil
+		}
+		z.mu.Unlock()
+
+		select {
+		case <-time.After(zoneLockPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// unlock releases the zone claimed by a matching call to lock.
+func (z *zoneLock) unlock(zone string) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.active[zone]--
+}
@@ -0,0 +1,86 @@
+package libdnsregery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestZoneLockExcludesConcurrentHolders(t *testing.T) {
+	var z zoneLock
+	ctx := context.Background()
+
+	if err := z.lock(ctx, "example.com"); err != nil {
+		t.Fatalf("lock() error = %v, want nil", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		if err := z.lock(ctx, "example.com"); err != nil {
+			t.Errorf("second lock() error = %v, want nil", err)
+		}
+		z.unlock("example.com")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second lock() returned before the first was released")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	z.unlock("example.com")
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second lock() never acquired the zone after it was released")
+	}
+}
+
+func TestZoneLockReturnsOnContextCancel(t *testing.T) {
+	var z zoneLock
+	ctx := context.Background()
+
+	if err := z.lock(ctx, "example.com"); err != nil {
+		t.Fatalf("lock() error = %v, want nil", err)
+	}
+	defer z.unlock("example.com")
+
+	cancelCtx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := z.lock(cancelCtx, "example.com")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("lock() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("lock() took %v to return after context deadline", elapsed)
+	}
+}
+
+func TestZoneLockAllowsDistinctZonesConcurrently(t *testing.T) {
+	var z zoneLock
+	ctx := context.Background()
+
+	if err := z.lock(ctx, "a.example.com"); err != nil {
+		t.Fatalf("lock(a) error = %v, want nil", err)
+	}
+	defer z.unlock("a.example.com")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- z.lock(ctx, "b.example.com")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("lock(b) error = %v, want nil", err)
+		}
+		z.unlock("b.example.com")
+	case <-time.After(time.Second):
+		t.Fatal("lock() on a distinct zone blocked on an unrelated zone's holder")
+	}
+}